@@ -1,6 +1,9 @@
 package tthsd
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"strings"
+)
 
 // DownloadEvent 是 DLL 回调中的事件结构（对应 Rust 中的 Event）
 type DownloadEvent struct {
@@ -10,9 +13,43 @@ type DownloadEvent struct {
 	ID       string `json:"ID"`
 }
 
-// CallbackData 是回调中附带的数据
+// CallbackData 是回调中附带数据的原始 map 形式，保留用于访问尚未被
+// UpdateData/MsgData/ErrData 覆盖的字段，向前兼容原生库新增的字段。
 type CallbackData map[string]interface{}
 
+// UpdateData 是 EventUpdate 事件的负载
+type UpdateData struct {
+	Downloaded int64 `json:"Downloaded"`
+	Total      int64 `json:"Total"`
+	Speed      int64 `json:"Speed"`
+}
+
+// MsgData 是 EventMsg 事件的负载
+type MsgData struct {
+	Text  string `json:"Text"`
+	Level string `json:"Level"`
+}
+
+// ErrorCode 对 EventErr 事件做分类，便于调用方针对性重试/告警
+type ErrorCode string
+
+const (
+	ErrNetwork    ErrorCode = "network"     // 连接/超时/DNS 等网络层错误
+	ErrHTTPStatus ErrorCode = "http_status" // 远端返回非成功状态码
+	ErrDiskFull   ErrorCode = "disk_full"   // 本地磁盘空间不足
+	ErrChecksum   ErrorCode = "checksum"    // 校验和不匹配
+	ErrCanceled   ErrorCode = "canceled"    // 任务被主动取消/停止
+	ErrUnknown    ErrorCode = "unknown"     // 无法归类
+)
+
+// ErrData 是 EventErr 事件的负载
+type ErrData struct {
+	Code      ErrorCode `json:"Code"`
+	Error     string    `json:"Error"`
+	Retryable bool      `json:"Retryable"`
+	URL       string    `json:"URL"`
+}
+
 // DownloadTask 是下载任务描述
 type DownloadTask struct {
 	URL      string `json:"url"`
@@ -32,23 +69,71 @@ const (
 	EventErr      = "err"      // 错误
 )
 
-// DownloadEventMsg 是通过 channel 传递的事件消息
+// DownloadEventMsg 是通过 channel 传递的事件消息。
+//
+// Payload 按 Event.Type 解析为具体类型（*UpdateData/*MsgData/*ErrData），
+// 解析失败或事件类型未被覆盖时为 nil，调用方可以退化到 Raw 读取原始字段。
 type DownloadEventMsg struct {
-	Event DownloadEvent
-	Data  CallbackData
+	Event   DownloadEvent
+	Payload interface{}
+	Raw     CallbackData
 }
 
-// parseCallback 内部使用：解析 JSON 回调参数
-func parseCallback(eventJSON, dataJSON string) (DownloadEvent, CallbackData) {
+// parseCallback 内部使用：解析 JSON 回调参数，返回事件头、原始 map
+// 以及按 Event.Type 解析出的类型化负载。
+func parseCallback(eventJSON, dataJSON string) (DownloadEvent, CallbackData, interface{}) {
 	var event DownloadEvent
-	var data CallbackData
+	var raw CallbackData
 
 	if eventJSON != "" {
 		_ = json.Unmarshal([]byte(eventJSON), &event)
 	}
 	if dataJSON != "" {
-		_ = json.Unmarshal([]byte(dataJSON), &data)
+		_ = json.Unmarshal([]byte(dataJSON), &raw)
+	}
+
+	var payload interface{}
+	if dataJSON != "" {
+		switch event.Type {
+		case EventUpdate:
+			var d UpdateData
+			if json.Unmarshal([]byte(dataJSON), &d) == nil {
+				payload = &d
+			}
+		case EventMsg:
+			var d MsgData
+			if json.Unmarshal([]byte(dataJSON), &d) == nil {
+				payload = &d
+			}
+		case EventErr:
+			var d ErrData
+			if json.Unmarshal([]byte(dataJSON), &d) == nil {
+				if d.Code == "" {
+					d.Code = classifyError(d.Error)
+				}
+				payload = &d
+			}
+		}
 	}
 
-	return event, data
+	return event, raw, payload
+}
+
+// classifyError 在原生库没有下发 Code 字段时，退化为对 Error 文案做字符串嗅探。
+func classifyError(msg string) ErrorCode {
+	lower := strings.ToLower(msg)
+	switch {
+	case strings.Contains(lower, "timeout"), strings.Contains(lower, "connection"), strings.Contains(lower, "dns"):
+		return ErrNetwork
+	case strings.Contains(lower, "status"), strings.Contains(lower, "http"):
+		return ErrHTTPStatus
+	case strings.Contains(lower, "disk"), strings.Contains(lower, "space"):
+		return ErrDiskFull
+	case strings.Contains(lower, "checksum"), strings.Contains(lower, "hash"):
+		return ErrChecksum
+	case strings.Contains(lower, "cancel"):
+		return ErrCanceled
+	default:
+		return ErrUnknown
+	}
 }
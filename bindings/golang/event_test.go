@@ -0,0 +1,134 @@
+package tthsd
+
+import (
+	"testing"
+)
+
+// TestParseCallbackTypedPayloads 验证 parseCallback 按 Event.Type 把
+// dataJSON 解析成对应的类型化 Payload（UpdateData/MsgData/ErrData），
+// 同时 Raw 仍然保留原始字段，供尚未被类型化覆盖的字段兜底访问。
+func TestParseCallbackTypedPayloads(t *testing.T) {
+	event, raw, payload := parseCallback(
+		`{"Type":"update","Name":"n","ShowName":"a.bin","ID":"task-1"}`,
+		`{"Downloaded":100,"Total":1000,"Speed":50}`,
+	)
+
+	if event.Type != EventUpdate || event.ID != "task-1" || event.ShowName != "a.bin" {
+		t.Fatalf("事件头解析不符合预期: %+v", event)
+	}
+
+	d, ok := payload.(*UpdateData)
+	if !ok {
+		t.Fatalf("update 事件的 Payload 应当是 *UpdateData，得到 %T", payload)
+	}
+	if d.Downloaded != 100 || d.Total != 1000 || d.Speed != 50 {
+		t.Fatalf("UpdateData 字段解析不符合预期: %+v", d)
+	}
+
+	if v, ok := raw["Downloaded"].(float64); !ok || v != 100 {
+		t.Fatalf("Raw 应当保留原始字段用于兜底: %+v", raw)
+	}
+}
+
+// TestParseCallbackMsgPayload 验证 EventMsg 解析为 *MsgData。
+func TestParseCallbackMsgPayload(t *testing.T) {
+	_, _, payload := parseCallback(
+		`{"Type":"msg"}`,
+		`{"Text":"hello","Level":"info"}`,
+	)
+
+	d, ok := payload.(*MsgData)
+	if !ok {
+		t.Fatalf("msg 事件的 Payload 应当是 *MsgData，得到 %T", payload)
+	}
+	if d.Text != "hello" || d.Level != "info" {
+		t.Fatalf("MsgData 字段解析不符合预期: %+v", d)
+	}
+}
+
+// TestParseCallbackErrPayloadKeepsExplicitCode 验证当原生库已经下发了
+// Code 字段时，parseCallback 不会用字符串嗅探覆盖它。
+func TestParseCallbackErrPayloadKeepsExplicitCode(t *testing.T) {
+	_, _, payload := parseCallback(
+		`{"Type":"err"}`,
+		`{"Code":"checksum","Error":"connection timeout","Retryable":true,"URL":"https://example.com/a.bin"}`,
+	)
+
+	d, ok := payload.(*ErrData)
+	if !ok {
+		t.Fatalf("err 事件的 Payload 应当是 *ErrData，得到 %T", payload)
+	}
+	if d.Code != ErrChecksum {
+		t.Fatalf("显式给出的 Code 不应被字符串嗅探覆盖，得到 %v", d.Code)
+	}
+	if !d.Retryable || d.URL != "https://example.com/a.bin" {
+		t.Fatalf("ErrData 其余字段解析不符合预期: %+v", d)
+	}
+}
+
+// TestParseCallbackErrPayloadClassifiesMissingCode 验证原生库没有下发
+// Code 字段时，parseCallback 退化为对 Error 文案做字符串嗅探分类。
+func TestParseCallbackErrPayloadClassifiesMissingCode(t *testing.T) {
+	_, _, payload := parseCallback(`{"Type":"err"}`, `{"Error":"connection timeout"}`)
+
+	d, ok := payload.(*ErrData)
+	if !ok {
+		t.Fatalf("err 事件的 Payload 应当是 *ErrData，得到 %T", payload)
+	}
+	if d.Code != ErrNetwork {
+		t.Fatalf("缺失 Code 时应按文案嗅探分类为 network，得到 %v", d.Code)
+	}
+}
+
+// TestParseCallbackEmptyDataJSON 验证 dataJSON 为空字符串时（例如没有
+// 负载的会话级事件）不会产生 Payload，也不会报错。
+func TestParseCallbackEmptyDataJSON(t *testing.T) {
+	event, raw, payload := parseCallback(`{"Type":"end"}`, "")
+
+	if event.Type != EventEnd {
+		t.Fatalf("事件类型解析不符合预期: %+v", event)
+	}
+	if raw != nil {
+		t.Fatalf("dataJSON 为空时 Raw 应当为 nil，得到 %+v", raw)
+	}
+	if payload != nil {
+		t.Fatalf("dataJSON 为空时不应产生 Payload，得到 %+v", payload)
+	}
+}
+
+// TestParseCallbackUnparsableDataFallsBackToNilPayload 验证 dataJSON 与
+// Event.Type 期望的结构不匹配（类型化解析失败）时，Payload 退化为 nil，
+// 调用方仍可以从 Raw 读取原始字段。
+func TestParseCallbackUnparsableDataFallsBackToNilPayload(t *testing.T) {
+	_, raw, payload := parseCallback(`{"Type":"update"}`, `{"Downloaded":"not-a-number"}`)
+
+	if payload != nil {
+		t.Fatalf("类型不匹配时 Payload 应当为 nil，得到 %+v", payload)
+	}
+	if raw == nil {
+		t.Fatal("即使类型化解析失败，Raw 也应当保留原始字段")
+	}
+}
+
+// TestClassifyError 覆盖 classifyError 对各类错误文案的分类规则。
+func TestClassifyError(t *testing.T) {
+	cases := []struct {
+		msg  string
+		want ErrorCode
+	}{
+		{"connection timeout after 30s", ErrNetwork},
+		{"DNS lookup failed", ErrNetwork},
+		{"received HTTP status 503", ErrHTTPStatus},
+		{"no space left on disk", ErrDiskFull},
+		{"checksum mismatch", ErrChecksum},
+		{"hash verification failed", ErrChecksum},
+		{"task canceled by user", ErrCanceled},
+		{"something completely unexpected happened", ErrUnknown},
+	}
+
+	for _, c := range cases {
+		if got := classifyError(c.msg); got != c.want {
+			t.Errorf("classifyError(%q) = %v, want %v", c.msg, got, c.want)
+		}
+	}
+}
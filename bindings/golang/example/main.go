@@ -2,15 +2,22 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"os"
 	"os/signal"
 	"syscall"
 
 	tthsd "github.com/TTHSDownloader/TTHSDNext/bindings/golang"
+	"github.com/TTHSDownloader/TTHSDNext/bindings/golang/gateway"
 )
 
 func main() {
+	serve := flag.Bool("serve", false, "以 HTTP/WebSocket 网关模式运行")
+	addr := flag.String("addr", ":8080", "--serve 模式下的监听地址")
+	token := flag.String("token", "", "--serve 模式下校验的 X-TTHSD-Token（为空则不校验）")
+	flag.Parse()
+
 	// 1. 加载动态库（空字符串自动搜索）
 	dl, err := tthsd.Load("")
 	if err != nil {
@@ -19,6 +26,11 @@ func main() {
 	}
 	defer dl.Close()
 
+	if *serve {
+		runServe(dl, *addr, *token)
+		return
+	}
+
 	// 2. 启动下载
 	id, events, err := dl.StartDownload(
 		[]string{"https://example.com/file.zip"},
@@ -50,10 +62,8 @@ func main() {
 
 			switch evt.Event.Type {
 			case tthsd.EventUpdate:
-				downloaded, _ := evt.Data["Downloaded"].(float64)
-				total, _ := evt.Data["Total"].(float64)
-				if total > 0 {
-					pct := downloaded / total * 100
+				if d, ok := evt.Payload.(*tthsd.UpdateData); ok && d.Total > 0 {
+					pct := float64(d.Downloaded) / float64(d.Total) * 100
 					fmt.Printf("\r[%s] 进度: %.1f%%", evt.Event.ShowName, pct)
 				}
 
@@ -69,14 +79,16 @@ func main() {
 				return
 
 			case tthsd.EventErr:
-				errMsg, _ := evt.Data["Error"].(string)
-				fmt.Fprintf(os.Stderr, "\n❌ 错误: %s\n", errMsg)
+				if d, ok := evt.Payload.(*tthsd.ErrData); ok {
+					fmt.Fprintf(os.Stderr, "\n❌ 错误[%s]: %s\n", d.Code, d.Error)
+				}
 				dl.StopDownload(id)
 				return
 
 			case tthsd.EventMsg:
-				text, _ := evt.Data["Text"].(string)
-				fmt.Printf("\n📢 %s\n", text)
+				if d, ok := evt.Payload.(*tthsd.MsgData); ok {
+					fmt.Printf("\n📢 %s\n", d.Text)
+				}
 			}
 
 		case <-sigCh:
@@ -86,3 +98,22 @@ func main() {
 		}
 	}
 }
+
+// runServe 以 --serve 模式启动 HTTP/WebSocket 网关，阻塞直至收到 Ctrl-C
+func runServe(dl *tthsd.TTHSDownloader, addr, token string) {
+	gw := gateway.New(dl, gateway.WithAuthToken(token))
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-sigCh
+		fmt.Println("\n中断，正在退出网关...")
+		os.Exit(0)
+	}()
+
+	fmt.Printf("网关监听于 %s\n", addr)
+	if err := gw.ListenAndServe(addr); err != nil {
+		fmt.Fprintf(os.Stderr, "网关启动失败: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,272 @@
+// Package gateway 把一个 *tthsd.TTHSDownloader 包装成 HTTP/WebSocket 服务，
+// 使下载器可以被跨进程、跨网络调用（适合由反向代理做 TLS/鉴权前置）。
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	tthsd "github.com/TTHSDownloader/TTHSDNext/bindings/golang"
+)
+
+const (
+	wsWriteWait  = 10 * time.Second
+	wsPongWait   = 60 * time.Second
+	wsPingPeriod = (wsPongWait * 9) / 10
+)
+
+// Gateway 把下载器操作映射为 REST/WebSocket 接口：
+//
+//	POST /downloads              提交一批 URL，返回下载器 ID
+//	POST /downloads/{id}/pause   暂停
+//	POST /downloads/{id}/resume  恢复
+//	POST /downloads/{id}/stop    停止并销毁
+//	GET  /downloads/{id}         返回聚合状态快照（JSON）
+//	GET  /downloads/{id}/events  升级为 WebSocket，推送该下载器的原始事件
+type Gateway struct {
+	dl        *tthsd.TTHSDownloader
+	authToken string
+	upgrader  websocket.Upgrader
+
+	monitorsMu sync.Mutex
+	monitors   map[int]*tthsd.DownloadMonitor
+}
+
+// Option 配置 New 的可选参数
+type Option func(*Gateway)
+
+// WithAuthToken 要求请求携带匹配的 X-TTHSD-Token 头，空字符串表示不校验。
+// 网关本身不做 TLS，生产环境应由反向代理终结 HTTPS 后再转发到这里。
+func WithAuthToken(token string) Option {
+	return func(g *Gateway) { g.authToken = token }
+}
+
+// New 创建一个包装 dl 的 Gateway
+func New(dl *tthsd.TTHSDownloader, opts ...Option) *Gateway {
+	g := &Gateway{
+		dl:       dl,
+		monitors: make(map[int]*tthsd.DownloadMonitor),
+		upgrader: websocket.Upgrader{
+			ReadBufferSize:  4096,
+			WriteBufferSize: 4096,
+			CheckOrigin:     func(r *http.Request) bool { return true },
+		},
+	}
+	for _, opt := range opts {
+		opt(g)
+	}
+	return g
+}
+
+// Handler 返回可以直接交给 http.Server 的路由处理器
+func (g *Gateway) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/downloads", g.withAuth(g.handleSubmit))
+	mux.HandleFunc("/downloads/", g.withAuth(g.handleDownloadPath))
+	return mux
+}
+
+// ListenAndServe 在 addr 上启动网关（阻塞调用）
+func (g *Gateway) ListenAndServe(addr string) error {
+	return http.ListenAndServe(addr, g.Handler())
+}
+
+func (g *Gateway) withAuth(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if g.authToken != "" && r.Header.Get("X-TTHSD-Token") != g.authToken {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next(w, r)
+	}
+}
+
+type submitRequest struct {
+	URLs      []string              `json:"urls"`
+	SavePaths []string              `json:"save_paths"`
+	Options   tthsd.DownloadOptions `json:"options"`
+}
+
+type submitResponse struct {
+	ID int `json:"id"`
+}
+
+func (g *Gateway) handleSubmit(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req submitRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("invalid request body: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	id, _, err := g.dl.StartDownload(req.URLs, req.SavePaths, req.Options)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, submitResponse{ID: id})
+}
+
+// handleDownloadPath 分发 /downloads/{id}[/pause|resume|stop|events]
+func (g *Gateway) handleDownloadPath(w http.ResponseWriter, r *http.Request) {
+	rest := strings.TrimPrefix(r.URL.Path, "/downloads/")
+	parts := strings.Split(rest, "/")
+
+	id, err := strconv.Atoi(parts[0])
+	if err != nil || parts[0] == "" {
+		http.Error(w, "invalid download id", http.StatusBadRequest)
+		return
+	}
+
+	if len(parts) == 1 {
+		g.handleStatus(w, r, id)
+		return
+	}
+
+	switch parts[1] {
+	case "pause":
+		g.handleControl(w, r, id, g.dl.PauseDownload)
+	case "resume":
+		g.handleControl(w, r, id, g.dl.ResumeDownload)
+	case "stop":
+		g.handleControl(w, r, id, g.dl.StopDownload)
+	case "events":
+		g.handleEvents(w, r, id)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (g *Gateway) handleControl(w http.ResponseWriter, r *http.Request, id int, fn func(int) bool) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !fn(id) {
+		http.Error(w, "operation failed", http.StatusConflict)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (g *Gateway) handleStatus(w http.ResponseWriter, r *http.Request, id int) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	m, ok := g.monitorFor(id)
+	if !ok {
+		http.Error(w, "unknown download id", http.StatusNotFound)
+		return
+	}
+	writeJSON(w, http.StatusOK, m.Snapshot())
+}
+
+// handleEvents 把连接升级为 WebSocket，然后把 id 对应下载器的每个事件
+// 以 JSON 帧推送给客户端，直到事件 channel 关闭或客户端断开连接。
+// 连接维护与编辑器协作场景里的每会话 *websocket.Conn 模式一致：
+// 写失败或读循环出错即视为断开，随之退出并关闭连接。
+func (g *Gateway) handleEvents(w http.ResponseWriter, r *http.Request, id int) {
+	if !g.dl.IsActive(id) {
+		http.Error(w, "unknown download id", http.StatusNotFound)
+		return
+	}
+
+	conn, err := g.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	conn.SetPongHandler(func(string) error {
+		conn.SetReadDeadline(time.Now().Add(wsPongWait))
+		return nil
+	})
+	go func() {
+		// 消费客户端可能发来的控制帧（包括 close），读失败即代表连接已断开
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				conn.Close()
+				return
+			}
+		}
+	}()
+
+	events := g.dl.Events(id)
+	ticker := time.NewTicker(wsPingPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case msg, ok := <-events:
+			if !ok {
+				return
+			}
+			conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			if err := conn.WriteJSON(msg); err != nil {
+				return
+			}
+
+		case <-ticker.C:
+			conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// monitorFor 返回 id 对应的 DownloadMonitor，首次访问时惰性创建。
+// id 不对应任何存活的下载器时返回 (nil, false)，不会创建 monitor。
+//
+// 创建的 monitor 会在其事件 channel 关闭后（即下载器被 StopDownload 之后）从
+// g.monitors 里移除，避免 /downloads/{id} 被反复访问一个早已过期的 id
+// 时无限积累 monitor/goroutine。
+func (g *Gateway) monitorFor(id int) (*tthsd.DownloadMonitor, bool) {
+	g.monitorsMu.Lock()
+	if m, ok := g.monitors[id]; ok {
+		g.monitorsMu.Unlock()
+		return m, true
+	}
+	g.monitorsMu.Unlock()
+
+	m, ok := g.dl.Monitor(id)
+	if !ok {
+		return nil, false
+	}
+
+	g.monitorsMu.Lock()
+	g.monitors[id] = m
+	g.monitorsMu.Unlock()
+
+	go func() {
+		_ = m.Wait(context.Background())
+		g.monitorsMu.Lock()
+		if g.monitors[id] == m {
+			delete(g.monitors, id)
+		}
+		g.monitorsMu.Unlock()
+	}()
+
+	return m, true
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,233 @@
+// Package metrics 为 TTHSD 下载器暴露 Prometheus 指标，驱动数据来自
+// tthsd.Subscribe 注册的全局事件观察者，调用方不需要改动自己的事件消费循环。
+package metrics
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	tthsd "github.com/TTHSDownloader/TTHSDNext/bindings/golang"
+)
+
+// Collector 实现 prometheus.Collector，汇总 TTHSD 下载器的运行时指标：
+//
+//	tthsd_active_downloads                 当前活跃的任务数
+//	tthsd_bytes_downloaded_total{id,show_name} 累计下载字节数
+//	tthsd_download_speed_bytes{id}          最近一次 update 事件的瞬时速度
+//	tthsd_download_errors_total{code}       按错误码统计的失败次数
+//	tthsd_task_duration_seconds             单任务从 startOne 到终态的耗时
+//	tthsd_task_status{id,status}            任务当前状态（仅当前的非终态标签，值为 1；终态 done/error 不导出）
+type Collector struct {
+	active       prometheus.Gauge
+	bytesTotal   *prometheus.CounterVec
+	speed        *prometheus.GaugeVec
+	errorsTotal  *prometheus.CounterVec
+	taskDuration prometheus.Histogram
+	taskStatus   *prometheus.GaugeVec
+
+	unsubscribe func() // 从 tthsd.Subscribe 注销本 Collector 的观察者，供 Close 使用
+
+	mu             sync.Mutex
+	startedAt      map[string]time.Time // taskID -> startOne 时刻
+	lastDownloaded map[string]int64     // taskID -> 上一次观测到的累计下载量
+	lastStatus     map[string]string    // taskID -> 上一次上报的状态标签
+}
+
+// NewCollector 创建一个未注册到任何 tthsd 下载器的空白 Collector
+func NewCollector() *Collector {
+	return &Collector{
+		active: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "tthsd_active_downloads",
+			Help: "当前活跃（已 startOne 但未结束/出错）的任务数",
+		}),
+		bytesTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "tthsd_bytes_downloaded_total",
+			Help: "累计下载字节数",
+		}, []string{"id", "show_name"}),
+		speed: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "tthsd_download_speed_bytes",
+			Help: "最近一次 update 事件携带的瞬时下载速度（字节/秒）",
+		}, []string{"id"}),
+		errorsTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "tthsd_download_errors_total",
+			Help: "按 ErrorCode 统计的下载失败次数",
+		}, []string{"code"}),
+		taskDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "tthsd_task_duration_seconds",
+			Help:    "单个任务从开始到结束/出错的耗时",
+			Buckets: prometheus.DefBuckets,
+		}),
+		taskStatus: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "tthsd_task_status",
+			Help: "任务当前状态，仅导出当前的非终态 (id, status) 组合，值为 1；旧状态和终态 done/error 的组合被删除",
+		}, []string{"id", "status"}),
+		startedAt:      make(map[string]time.Time),
+		lastDownloaded: make(map[string]int64),
+		lastStatus:     make(map[string]string),
+	}
+}
+
+// Describe 实现 prometheus.Collector
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	c.active.Describe(ch)
+	c.bytesTotal.Describe(ch)
+	c.speed.Describe(ch)
+	c.errorsTotal.Describe(ch)
+	c.taskDuration.Describe(ch)
+	c.taskStatus.Describe(ch)
+}
+
+// Collect 实现 prometheus.Collector
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.active.Collect(ch)
+	c.bytesTotal.Collect(ch)
+	c.speed.Collect(ch)
+	c.errorsTotal.Collect(ch)
+	c.taskDuration.Collect(ch)
+	c.taskStatus.Collect(ch)
+}
+
+// Attach 创建一个 Collector 并把它接到 dl 提交的每个任务上：事件经由
+// tthsd.Subscribe 的进程级全局观察者送达，但 Collector 会用 dl.OwnsTask
+// 过滤掉不属于 dl 的事件，因此同一进程内的多个 TTHSDownloader 实例
+// 分别 Attach 互不干扰。调用方的事件消费循环不需要任何改动，配合
+// c.ListenAndServe 或自行 prometheus.MustRegister(c) 使用。
+//
+// 不再需要时应调用 c.Close() 注销观察者；重复 Attach 同一个 dl 而不
+// Close 旧的 Collector 会让两份观察者同时统计，导致指标翻倍。
+func Attach(dl *tthsd.TTHSDownloader) *Collector {
+	c := NewCollector()
+	c.unsubscribe = tthsd.Subscribe(func(msg tthsd.DownloadEventMsg) {
+		if !dl.OwnsTask(msg.Event.ID) {
+			return
+		}
+		c.observe(msg)
+	})
+	return c
+}
+
+// Close 注销 Attach 时注册的全局观察者。Collector 本身以及它已经累计的
+// 指标值不受影响，但之后的事件不会再驱动它——重新 Attach 前应先 Close
+// 旧的 Collector，否则同一个 dl 会被重复订阅、指标被重复计数。
+func (c *Collector) Close() {
+	if c.unsubscribe != nil {
+		c.unsubscribe()
+	}
+}
+
+// ListenAndServe 启动一个独立的 HTTP 端点，在 /metrics 上暴露 c 的指标，
+// 供 Prometheus 抓取。
+func (c *Collector) ListenAndServe(addr string) error {
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(c)
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(reg, promhttp.HandlerOpts{}))
+	return http.ListenAndServe(addr, mux)
+}
+
+func (c *Collector) observe(msg tthsd.DownloadEventMsg) {
+	id := msg.Event.ID
+	if id == "" {
+		return
+	}
+
+	switch msg.Event.Type {
+	case tthsd.EventStartOne:
+		c.mu.Lock()
+		c.startedAt[id] = time.Now()
+		c.mu.Unlock()
+		c.active.Inc()
+		c.setStatus(id, "running")
+
+	case tthsd.EventUpdate:
+		if d, ok := msg.Payload.(*tthsd.UpdateData); ok {
+			c.bytesTotal.WithLabelValues(id, msg.Event.ShowName).Add(float64(c.progressDelta(id, d.Downloaded)))
+			c.speed.WithLabelValues(id).Set(float64(d.Speed))
+		}
+		c.setStatus(id, "running")
+
+	case tthsd.EventEndOne:
+		c.active.Dec()
+		c.observeDuration(id)
+		c.setStatus(id, "done")
+		c.cleanupTaskSeries(id, msg.Event.ShowName)
+
+	case tthsd.EventErr:
+		code := string(tthsd.ErrUnknown)
+		if d, ok := msg.Payload.(*tthsd.ErrData); ok {
+			code = string(d.Code)
+		}
+		c.errorsTotal.WithLabelValues(code).Inc()
+		c.active.Dec()
+		c.observeDuration(id)
+		c.setStatus(id, "error")
+		c.cleanupTaskSeries(id, msg.Event.ShowName)
+	}
+}
+
+// cleanupTaskSeries 在任务到达终态（done/error）后移除它名下按任务 ID
+// 打标的 bytesTotal/speed 时间序列。任务 ID 对每次下载都是唯一的，
+// 不清理就会让这两个指标的基数随着下载过的任务数无限增长
+// （tthsd_task_status 的对应清理在 setStatus 中处理）。
+func (c *Collector) cleanupTaskSeries(id, showName string) {
+	c.bytesTotal.DeleteLabelValues(id, showName)
+	c.speed.DeleteLabelValues(id)
+}
+
+// progressDelta 把 UpdateData.Downloaded 的累计值换算成相对上一次观测的增量，
+// 这样 bytesTotal 才是单调递增的计数器，而不是把累计值反复累加。
+func (c *Collector) progressDelta(id string, downloaded int64) int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delta := downloaded - c.lastDownloaded[id]
+	c.lastDownloaded[id] = downloaded
+	if delta < 0 {
+		return 0
+	}
+	return delta
+}
+
+func (c *Collector) observeDuration(id string) {
+	c.mu.Lock()
+	start, ok := c.startedAt[id]
+	delete(c.startedAt, id)
+	delete(c.lastDownloaded, id)
+	c.mu.Unlock()
+
+	if ok {
+		c.taskDuration.Observe(time.Since(start).Seconds())
+	}
+}
+
+// setStatus 把 taskStatus{id, status} 置 1，并移除该任务上一次上报的状态，
+// 避免同一个任务同时在两个状态标签下都显示为活跃。到达终态（done/error）
+// 时不再保留本次的标签组合——任务 ID 对每次下载唯一，一直保留会让
+// tthsd_task_status 的基数随着完成过的任务数无限增长。
+func (c *Collector) setStatus(id, status string) {
+	terminal := status == "done" || status == "error"
+
+	c.mu.Lock()
+	prev := c.lastStatus[id]
+	if terminal {
+		delete(c.lastStatus, id)
+	} else {
+		c.lastStatus[id] = status
+	}
+	c.mu.Unlock()
+
+	if prev != "" && prev != status {
+		c.taskStatus.DeleteLabelValues(id, prev)
+	}
+
+	if terminal {
+		c.taskStatus.DeleteLabelValues(id, status)
+		return
+	}
+	c.taskStatus.WithLabelValues(id, status).Set(1)
+}
@@ -0,0 +1,98 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	tthsd "github.com/TTHSDownloader/TTHSDNext/bindings/golang"
+)
+
+// TestCollectorProgressDelta 验证 progressDelta 把累计的 Downloaded 值换算
+// 成相对上一次观测的增量，且不会因为一次乱序/回退的观测值产生负增量。
+func TestCollectorProgressDelta(t *testing.T) {
+	c := NewCollector()
+
+	if d := c.progressDelta("task-1", 100); d != 100 {
+		t.Fatalf("首次观测应相对 0 计算增量，得到 %d", d)
+	}
+	if d := c.progressDelta("task-1", 250); d != 150 {
+		t.Fatalf("第二次观测应得到 150 的增量，得到 %d", d)
+	}
+	if d := c.progressDelta("task-1", 200); d != 0 {
+		t.Fatalf("观测值回退时增量应钳制为 0，得到 %d", d)
+	}
+}
+
+// TestCollectorObserveAccumulatesBytes 验证连续的 update 事件会把增量
+// 累加进 bytesTotal，而不是把累计值重复相加。
+func TestCollectorObserveAccumulatesBytes(t *testing.T) {
+	c := NewCollector()
+	const id, showName = "task-1", "a.bin"
+
+	c.observe(tthsd.DownloadEventMsg{Event: tthsd.DownloadEvent{Type: tthsd.EventStartOne, ID: id, ShowName: showName}})
+	c.observe(tthsd.DownloadEventMsg{
+		Event:   tthsd.DownloadEvent{Type: tthsd.EventUpdate, ID: id, ShowName: showName},
+		Payload: &tthsd.UpdateData{Downloaded: 100, Total: 1000, Speed: 50},
+	})
+	c.observe(tthsd.DownloadEventMsg{
+		Event:   tthsd.DownloadEvent{Type: tthsd.EventUpdate, ID: id, ShowName: showName},
+		Payload: &tthsd.UpdateData{Downloaded: 300, Total: 1000, Speed: 80},
+	})
+
+	if got := testutil.ToFloat64(c.bytesTotal.WithLabelValues(id, showName)); got != 300 {
+		t.Fatalf("bytesTotal 应等于最后一次观测到的累计下载量 300，得到 %v", got)
+	}
+	if got := testutil.ToFloat64(c.speed.WithLabelValues(id)); got != 80 {
+		t.Fatalf("speed 应反映最近一次 update 的瞬时速度 80，得到 %v", got)
+	}
+}
+
+// TestCollectorSetStatusTogglesSingleActiveLabel 验证同一个任务前后上报
+// 两个不同状态时，旧状态标签被移除，只留下最新状态为 1。
+func TestCollectorSetStatusTogglesSingleActiveLabel(t *testing.T) {
+	c := NewCollector()
+	const id = "task-1"
+
+	c.setStatus(id, "running")
+	if got := testutil.ToFloat64(c.taskStatus.WithLabelValues(id, "running")); got != 1 {
+		t.Fatalf("running 状态应置 1，得到 %v", got)
+	}
+
+	c.setStatus(id, "done")
+	// done 是终态，setStatus 应当直接删除这两个标签组合，而不是把 running 置 0
+	// 或者让 done 永远挂在时间序列里。WithLabelValues 会在查询时重新创建一个
+	// 全新的、值为 0 的子指标——借此断言原先的记录确已被删除。
+	if got := testutil.ToFloat64(c.taskStatus.WithLabelValues(id, "running")); got != 0 {
+		t.Fatalf("running 状态本应在切换到 done 后被移除，得到 %v", got)
+	}
+	if got := testutil.ToFloat64(c.taskStatus.WithLabelValues(id, "done")); got != 0 {
+		t.Fatalf("done 是终态，不应被保留为常驻的 1 值时间序列，得到 %v", got)
+	}
+}
+
+// TestCollectorCleanupOnTerminalEvent 验证任务到达终态（endOne/err）之后，
+// bytesTotal/speed/taskStatus 里属于该任务 ID 的时间序列被移除，不会随着
+// 完成过的任务数无限增长（这是 Collector 面向"监控下载器舰队"场景的核心
+// 基数控制手段）。
+func TestCollectorCleanupOnTerminalEvent(t *testing.T) {
+	c := NewCollector()
+	const id, showName = "task-1", "a.bin"
+
+	c.observe(tthsd.DownloadEventMsg{Event: tthsd.DownloadEvent{Type: tthsd.EventStartOne, ID: id, ShowName: showName}})
+	c.observe(tthsd.DownloadEventMsg{
+		Event:   tthsd.DownloadEvent{Type: tthsd.EventUpdate, ID: id, ShowName: showName},
+		Payload: &tthsd.UpdateData{Downloaded: 500, Total: 1000, Speed: 42},
+	})
+	c.observe(tthsd.DownloadEventMsg{Event: tthsd.DownloadEvent{Type: tthsd.EventEndOne, ID: id, ShowName: showName}})
+
+	if got := testutil.ToFloat64(c.bytesTotal.WithLabelValues(id, showName)); got != 0 {
+		t.Fatalf("endOne 之后 bytesTotal{%s} 本应被清理，重新创建应得到 0，得到 %v", id, got)
+	}
+	if got := testutil.ToFloat64(c.speed.WithLabelValues(id)); got != 0 {
+		t.Fatalf("endOne 之后 speed{%s} 本应被清理，重新创建应得到 0，得到 %v", id, got)
+	}
+	if got := testutil.ToFloat64(c.taskStatus.WithLabelValues(id, "done")); got != 0 {
+		t.Fatalf("endOne 之后 taskStatus{%s,done} 本应被清理，重新创建应得到 0，得到 %v", id, got)
+	}
+}
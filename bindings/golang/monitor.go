@@ -0,0 +1,168 @@
+package tthsd
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// monitorSpeedAlpha 是速度估算使用的指数加权移动平均（EWMA）系数
+const monitorSpeedAlpha = 0.3
+
+// monitorPublishInterval 限制 Subscribe 的推送频率（≤10 Hz），
+// 避免下游被原生库高频吐出的 update 事件淹没
+const monitorPublishInterval = time.Second / 10
+
+// DownloadSnapshot 是某个下载器在某一时刻的聚合进度快照
+type DownloadSnapshot struct {
+	DownloaderID int
+	Downloaded   int64
+	Total        int64
+	Speed        float64       // 字节/秒，EWMA 平滑
+	ETA          time.Duration // 0 表示尚无法估算
+	Status       TaskStatus    // 复用 Store 中的任务状态机（见 store.go）
+}
+
+// DownloadMonitor 在后台消费某个下载器的事件流，维护一份聚合状态快照，
+// 让调用方可以轮询/订阅进度，而不必自己解析 CallbackData 里的
+// map[string]interface{}。
+type DownloadMonitor struct {
+	mu       sync.RWMutex
+	snapshot DownloadSnapshot
+
+	subsMu sync.Mutex
+	subs   map[chan DownloadSnapshot]struct{}
+
+	done chan struct{}
+}
+
+// Monitor 启动一个后台 goroutine 消费 id 对应下载器的事件（通过旁路 channel，
+// 不影响 StartDownload/GetDownloader 返回给调用方的原始 channel），
+// 返回可用于查询/订阅聚合状态的 DownloadMonitor。
+//
+// 第二个返回值在 id 不对应任何存活的下载器时为 false，此时不会创建旁路
+// channel 或后台 goroutine，调用方应把它当作"未找到"处理，而不是得到一个
+// 永远不会有进展的空快照。
+func (dl *TTHSDownloader) Monitor(id int) (*DownloadMonitor, bool) {
+	if !downloaderExists(id) {
+		return nil, false
+	}
+
+	m := &DownloadMonitor{
+		snapshot: DownloadSnapshot{DownloaderID: id, Status: StatusReady},
+		subs:     make(map[chan DownloadSnapshot]struct{}),
+		done:     make(chan struct{}),
+	}
+	go m.run(registerMonitorTap(id))
+	return m, true
+}
+
+func (m *DownloadMonitor) run(events <-chan DownloadEventMsg) {
+	defer close(m.done)
+
+	var lastSampleAt time.Time
+	var lastDownloaded int64
+
+	ticker := time.NewTicker(monitorPublishInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case msg, ok := <-events:
+			if !ok {
+				return
+			}
+			m.apply(msg, &lastSampleAt, &lastDownloaded)
+
+		case <-ticker.C:
+			m.publish()
+		}
+	}
+}
+
+// apply 把一条事件并入快照；速度用 EWMA 在相邻两次 update 之间的瞬时速率上平滑。
+func (m *DownloadMonitor) apply(msg DownloadEventMsg, lastSampleAt *time.Time, lastDownloaded *int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	switch msg.Event.Type {
+	case EventUpdate:
+		now := time.Now()
+		downloaded, total := parseProgress(msg)
+
+		if !lastSampleAt.IsZero() {
+			if dt := now.Sub(*lastSampleAt).Seconds(); dt > 0 {
+				inst := float64(downloaded-*lastDownloaded) / dt
+				if m.snapshot.Speed == 0 {
+					m.snapshot.Speed = inst
+				} else {
+					m.snapshot.Speed = monitorSpeedAlpha*inst + (1-monitorSpeedAlpha)*m.snapshot.Speed
+				}
+			}
+		}
+		*lastSampleAt, *lastDownloaded = now, downloaded
+
+		m.snapshot.Downloaded, m.snapshot.Total = downloaded, total
+		m.snapshot.Status = StatusRunning
+		if m.snapshot.Speed > 0 && total > downloaded {
+			m.snapshot.ETA = time.Duration(float64(total-downloaded) / m.snapshot.Speed * float64(time.Second))
+		}
+
+	case EventStartOne:
+		m.snapshot.Status = StatusRunning
+
+	case EventEndOne, EventEnd:
+		m.snapshot.Status = StatusDone
+		m.snapshot.Speed = 0
+		m.snapshot.ETA = 0
+
+	case EventErr:
+		m.snapshot.Status = StatusError
+		m.snapshot.Speed = 0
+		m.snapshot.ETA = 0
+	}
+}
+
+func (m *DownloadMonitor) publish() {
+	m.mu.RLock()
+	snap := m.snapshot
+	m.mu.RUnlock()
+
+	m.subsMu.Lock()
+	for ch := range m.subs {
+		select {
+		case ch <- snap:
+		default:
+			// 订阅者消费不及时，丢弃这次推送，下一次 tick 会带来更新的快照
+		}
+	}
+	m.subsMu.Unlock()
+}
+
+// Snapshot 返回当前的聚合状态快照
+func (m *DownloadMonitor) Snapshot() DownloadSnapshot {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.snapshot
+}
+
+// Subscribe 返回一个按 ≤10 Hz 节流推送快照的 channel。channel 不会自动关闭，
+// 调用方应结合 Wait 判断下载是否已经结束。
+func (m *DownloadMonitor) Subscribe() <-chan DownloadSnapshot {
+	ch := make(chan DownloadSnapshot, 1)
+	m.subsMu.Lock()
+	m.subs[ch] = struct{}{}
+	m.subsMu.Unlock()
+	return ch
+}
+
+// Wait 阻塞直到下载器的事件 channel 关闭（即该下载器被 StopDownload 之后），
+// 或 ctx 被取消。end/err 事件本身不会让 Wait 返回。
+func (m *DownloadMonitor) Wait(ctx context.Context) error {
+	select {
+	case <-m.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,94 @@
+package tthsd
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// TestMonitorApplyEWMASpeed 验证 apply 对连续两次 update 事件的瞬时速率做
+// EWMA 平滑，且 ETA 按平滑后的速度估算剩余时间。
+func TestMonitorApplyEWMASpeed(t *testing.T) {
+	m := &DownloadMonitor{snapshot: DownloadSnapshot{Status: StatusReady}}
+	var lastSampleAt time.Time
+	var lastDownloaded int64
+
+	t0 := time.Now()
+	m.apply(DownloadEventMsg{
+		Event:   DownloadEvent{Type: EventUpdate},
+		Payload: &UpdateData{Downloaded: 1000, Total: 10000},
+	}, &lastSampleAt, &lastDownloaded)
+
+	// 第一条 update 还没有上一次采样可供求瞬时速率，速度应保持为 0。
+	if got := m.Snapshot().Speed; got != 0 {
+		t.Fatalf("首条 update 不应产生速度估算，得到 %v", got)
+	}
+
+	lastSampleAt = t0.Add(-time.Second)
+	m.apply(DownloadEventMsg{
+		Event:   DownloadEvent{Type: EventUpdate},
+		Payload: &UpdateData{Downloaded: 2000, Total: 10000},
+	}, &lastSampleAt, &lastDownloaded)
+
+	snap := m.Snapshot()
+	if snap.Speed <= 0 {
+		t.Fatalf("两次 update 之间应产生正的速度估算，得到 %v", snap.Speed)
+	}
+	if snap.Status != StatusRunning {
+		t.Fatalf("update 事件之后状态应为 running，得到 %v", snap.Status)
+	}
+	if snap.ETA <= 0 {
+		t.Fatalf("total > downloaded 且速度为正时应产生 ETA，得到 %v", snap.ETA)
+	}
+}
+
+// TestMonitorApplyTerminalStatesResetSpeed 验证 endOne/err 事件会把速度和
+// ETA 清零，避免展示一个已经终止的下载仍然"在跑"的陈旧估算。
+func TestMonitorApplyTerminalStatesResetSpeed(t *testing.T) {
+	m := &DownloadMonitor{snapshot: DownloadSnapshot{Status: StatusRunning, Speed: 123, ETA: time.Minute}}
+	var lastSampleAt time.Time
+	var lastDownloaded int64
+
+	m.apply(DownloadEventMsg{Event: DownloadEvent{Type: EventEndOne}}, &lastSampleAt, &lastDownloaded)
+
+	snap := m.Snapshot()
+	if snap.Status != StatusDone || snap.Speed != 0 || snap.ETA != 0 {
+		t.Fatalf("endOne 之后应当是 done/0速度/0 ETA，得到 %+v", snap)
+	}
+}
+
+// TestMonitorRejectsUnknownID 验证 Monitor 在 id 不对应任何存活下载器时
+// 拒绝创建旁路 channel/goroutine，而不是为任意整数常驻资源。
+func TestMonitorRejectsUnknownID(t *testing.T) {
+	dl := &TTHSDownloader{taskIDs: make(map[string]int)}
+
+	if m, ok := dl.Monitor(999999); ok || m != nil {
+		t.Fatalf("未知 id 的 Monitor 应返回 (nil, false)，得到 (%v, %v)", m, ok)
+	}
+}
+
+// TestMonitorAcceptsActiveID 验证当 id 对应一个已登记的存活 channel 时，
+// Monitor 能正常创建并在 channel 关闭后结束。
+func TestMonitorAcceptsActiveID(t *testing.T) {
+	const id = 42001
+	registerChannel(id)
+	defer unregisterChannel(id)
+
+	m, ok := (&TTHSDownloader{taskIDs: make(map[string]int)}).Monitor(id)
+	if !ok || m == nil {
+		t.Fatalf("已登记的 id 应当能创建 Monitor: ok=%v m=%v", ok, m)
+	}
+
+	unregisterChannel(id)
+
+	done := make(chan struct{})
+	go func() {
+		_ = m.Wait(context.Background())
+		close(done)
+	}()
+	select {
+	case <-time.After(time.Second):
+		t.Fatal("unregisterChannel 之后 Monitor 应当随 tap channel 关闭而退出")
+	case <-done:
+	}
+}
@@ -0,0 +1,378 @@
+package tthsd
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// TaskStatus 是任务在 Store 中持久化的生命周期状态
+type TaskStatus string
+
+const (
+	StatusReady   TaskStatus = "ready"   // 已创建，尚未启动
+	StatusRunning TaskStatus = "running" // 下载中
+	StatusPaused  TaskStatus = "paused"  // 已暂停
+	StatusDone    TaskStatus = "done"    // 已完成
+	StatusError   TaskStatus = "error"   // 出错终止
+)
+
+// TaskRecord 是 Store 中一条任务的持久化快照
+type TaskRecord struct {
+	ID           string // 任务 UUID，对应 DownloadTask.ID / DownloadEvent.ID
+	DownloaderID int    // 归属的下载器 ID（尚未提交给原生库时为 0）
+	URL          string
+	SavePath     string
+	Options      DownloadOptions
+	Downloaded   int64
+	Total        int64
+	Status       TaskStatus
+	UpdatedAt    time.Time
+}
+
+const createTasksTableSQL = `
+CREATE TABLE IF NOT EXISTS tasks (
+	id            TEXT PRIMARY KEY,
+	downloader_id INTEGER NOT NULL DEFAULT 0,
+	url           TEXT NOT NULL,
+	save_path     TEXT NOT NULL,
+	status        TEXT NOT NULL,
+	downloaded    INTEGER NOT NULL DEFAULT 0,
+	total         INTEGER NOT NULL DEFAULT 0,
+	options_json  TEXT NOT NULL DEFAULT '{}',
+	updated_at    INTEGER NOT NULL
+)`
+
+// Store 把任务状态持久化到 SQLite（modernc.org/sqlite，不依赖 CGO），
+// 使 TTHSDownloader 能在进程重启后通过 Resume 恢复未完成的下载。
+type Store struct {
+	db            *sql.DB
+	flushInterval time.Duration
+
+	mu      sync.Mutex
+	pending map[string]TaskRecord // 累积的进度更新，等待下一次批量落盘
+	closeCh chan struct{}
+	doneCh  chan struct{}
+}
+
+// StoreOption 配置 OpenStore 的可选参数
+type StoreOption func(*Store)
+
+// WithFlushInterval 设置进度更新的批量落盘间隔（默认 2 秒）
+func WithFlushInterval(d time.Duration) StoreOption {
+	return func(s *Store) { s.flushInterval = d }
+}
+
+// OpenStore 打开（不存在则创建）位于 path 的任务存储
+func OpenStore(path string, opts ...StoreOption) (*Store, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("[TTHSD] 打开任务存储失败: %w", err)
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("[TTHSD] 连接任务存储失败: %w", err)
+	}
+	if _, err := db.Exec(createTasksTableSQL); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("[TTHSD] 初始化任务表失败: %w", err)
+	}
+
+	s := &Store{
+		db:            db,
+		flushInterval: 2 * time.Second,
+		pending:       make(map[string]TaskRecord),
+		closeCh:       make(chan struct{}),
+		doneCh:        make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	go s.writeLoop()
+	return s, nil
+}
+
+// Put 立即写入/覆盖一条任务记录，适合创建任务、状态切换等低频写入。
+func (s *Store) Put(rec TaskRecord) error {
+	if rec.UpdatedAt.IsZero() {
+		rec.UpdatedAt = time.Now()
+	}
+	return s.upsert(rec)
+}
+
+func (s *Store) upsert(rec TaskRecord) error {
+	optsJSON, err := json.Marshal(rec.Options)
+	if err != nil {
+		return fmt.Errorf("[TTHSD] 序列化任务选项失败: %w", err)
+	}
+
+	_, err = s.db.Exec(`
+		INSERT INTO tasks (id, downloader_id, url, save_path, status, downloaded, total, options_json, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET
+			downloader_id = excluded.downloader_id,
+			url           = excluded.url,
+			save_path     = excluded.save_path,
+			status        = excluded.status,
+			downloaded    = excluded.downloaded,
+			total         = excluded.total,
+			options_json  = excluded.options_json,
+			updated_at    = excluded.updated_at`,
+		rec.ID, rec.DownloaderID, rec.URL, rec.SavePath,
+		string(rec.Status), rec.Downloaded, rec.Total, string(optsJSON), rec.UpdatedAt.Unix(),
+	)
+	if err != nil {
+		return fmt.Errorf("[TTHSD] 写入任务记录失败: %w", err)
+	}
+	return nil
+}
+
+// queueUpdate 把一次进度更新攒入批处理缓冲区，由 writeLoop 按 flushInterval 落盘，
+// 避免每个 update 事件都触发一次磁盘写入。
+func (s *Store) queueUpdate(rec TaskRecord) {
+	s.mu.Lock()
+	s.pending[rec.ID] = rec
+	s.mu.Unlock()
+}
+
+func (s *Store) writeLoop() {
+	defer close(s.doneCh)
+
+	ticker := time.NewTicker(s.flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.flush()
+		case <-s.closeCh:
+			s.flush()
+			return
+		}
+	}
+}
+
+func (s *Store) flush() {
+	s.mu.Lock()
+	batch := s.pending
+	s.pending = make(map[string]TaskRecord)
+	s.mu.Unlock()
+
+	for _, rec := range batch {
+		_ = s.upsert(rec) // 单条失败不应影响同一批其余记录的落盘
+	}
+}
+
+// List 返回存储中的全部任务记录
+func (s *Store) List() ([]TaskRecord, error) {
+	rows, err := s.db.Query(`SELECT id, downloader_id, url, save_path, status, downloaded, total, options_json, updated_at FROM tasks`)
+	if err != nil {
+		return nil, fmt.Errorf("[TTHSD] 查询任务列表失败: %w", err)
+	}
+	defer rows.Close()
+
+	var out []TaskRecord
+	for rows.Next() {
+		rec, err := scanTaskRecord(rows)
+		if err != nil {
+			return nil, fmt.Errorf("[TTHSD] 解析任务记录失败: %w", err)
+		}
+		out = append(out, rec)
+	}
+	return out, rows.Err()
+}
+
+// Get 按任务 UUID 查询单条记录
+func (s *Store) Get(id string) (TaskRecord, bool, error) {
+	row := s.db.QueryRow(`SELECT id, downloader_id, url, save_path, status, downloaded, total, options_json, updated_at FROM tasks WHERE id = ?`, id)
+	rec, err := scanTaskRecord(row)
+	if err == sql.ErrNoRows {
+		return TaskRecord{}, false, nil
+	}
+	if err != nil {
+		return TaskRecord{}, false, fmt.Errorf("[TTHSD] 查询任务记录失败: %w", err)
+	}
+	return rec, true, nil
+}
+
+// Delete 删除一条任务记录
+func (s *Store) Delete(id string) error {
+	if _, err := s.db.Exec(`DELETE FROM tasks WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("[TTHSD] 删除任务记录失败: %w", err)
+	}
+	return nil
+}
+
+// Close 落盘剩余的批量更新并关闭底层数据库连接
+func (s *Store) Close() error {
+	close(s.closeCh)
+	<-s.doneCh
+	return s.db.Close()
+}
+
+// teeEvents 把 ch 中的事件原样转发给调用方，同时把进度/状态同步进 Store。
+func (s *Store) teeEvents(ch <-chan DownloadEventMsg) <-chan DownloadEventMsg {
+	out := make(chan DownloadEventMsg, cap(ch))
+	go func() {
+		defer close(out)
+		for msg := range ch {
+			s.observe(msg)
+			out <- msg
+		}
+	}()
+	return out
+}
+
+// observe 把事件翻译成 Store 中的记录更新；update 走批量缓冲区，
+// 状态切换（startOne/endOne/err）立即落盘，避免丢失终态。
+func (s *Store) observe(msg DownloadEventMsg) {
+	taskID := msg.Event.ID
+	if taskID == "" {
+		return
+	}
+
+	rec, ok, err := s.Get(taskID)
+	if err != nil || !ok {
+		return
+	}
+
+	switch msg.Event.Type {
+	case EventUpdate:
+		rec.Downloaded, rec.Total = parseProgress(msg)
+		rec.Status = StatusRunning
+		rec.UpdatedAt = time.Now()
+		s.queueUpdate(rec)
+	case EventStartOne:
+		rec.Status = StatusRunning
+		rec.UpdatedAt = time.Now()
+		_ = s.Put(rec)
+	case EventEndOne:
+		rec.Status = StatusDone
+		rec.UpdatedAt = time.Now()
+		_ = s.Put(rec)
+	case EventErr:
+		rec.Status = StatusError
+		rec.UpdatedAt = time.Now()
+		_ = s.Put(rec)
+	}
+}
+
+// parseProgress 优先读取类型化的 UpdateData，解析失败（或原生库尚未下发
+// 该结构时）退化到原始 map，兼容旧版本原生库。
+func parseProgress(msg DownloadEventMsg) (downloaded, total int64) {
+	if d, ok := msg.Payload.(*UpdateData); ok {
+		return d.Downloaded, d.Total
+	}
+	if v, ok := msg.Raw["Downloaded"].(float64); ok {
+		downloaded = int64(v)
+	}
+	if v, ok := msg.Raw["Total"].(float64); ok {
+		total = int64(v)
+	}
+	return downloaded, total
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanTaskRecord(row rowScanner) (TaskRecord, error) {
+	var rec TaskRecord
+	var status, optsJSON string
+	var updatedAt int64
+
+	if err := row.Scan(&rec.ID, &rec.DownloaderID, &rec.URL, &rec.SavePath,
+		&status, &rec.Downloaded, &rec.Total, &optsJSON, &updatedAt); err != nil {
+		return TaskRecord{}, err
+	}
+
+	rec.Status = TaskStatus(status)
+	rec.UpdatedAt = time.Unix(updatedAt, 0)
+	_ = json.Unmarshal([]byte(optsJSON), &rec.Options)
+	return rec, nil
+}
+
+// Resume 枚举 Store 中所有非终态任务，按原下载器分组重新提交给原生库，
+// 已暂停的任务调用 ResumeDownload，其余（ready/running，例如进程异常退出
+// 后残留的记录）调用 StartDownloadByID 重新启动，并返回一个聚合了所有
+// 被恢复任务事件的 channel，以及本次实际启动的下载器 ID 列表——调用方
+// 需要这份列表才能在提前中断时对每一个恢复的下载器都调用 StopDownload
+// （一次 Resume 可能同时重启多个下载器，不存在单一 ID 可以代表全部）。
+func (dl *TTHSDownloader) Resume(ctx context.Context) (<-chan DownloadEventMsg, []int, error) {
+	if dl.store == nil {
+		return nil, nil, fmt.Errorf("[TTHSD] 未绑定 Store，无法恢复任务")
+	}
+
+	records, err := dl.store.List()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	groups := make(map[int][]TaskRecord)
+	for _, rec := range records {
+		if rec.Status == StatusDone || rec.Status == StatusError {
+			continue
+		}
+		groups[rec.DownloaderID] = append(groups[rec.DownloaderID], rec)
+	}
+
+	merged := make(chan DownloadEventMsg, 1024)
+	var wg sync.WaitGroup
+	var ids []int
+
+	for _, recs := range groups {
+		urls := make([]string, len(recs))
+		savePaths := make([]string, len(recs))
+		taskIDs := make([]string, len(recs))
+		wasPaused := false
+		for i, rec := range recs {
+			urls[i], savePaths[i], taskIDs[i] = rec.URL, rec.SavePath, rec.ID
+			if rec.Status == StatusPaused {
+				wasPaused = true
+			}
+		}
+
+		newID, ch, err := dl.getDownloader(urls, savePaths, taskIDs, recs[0].Options)
+		if err != nil {
+			continue
+		}
+
+		var resumed bool
+		if wasPaused {
+			resumed = dl.ResumeDownload(newID)
+		} else {
+			resumed = dl.StartDownloadByID(newID)
+		}
+		if !resumed {
+			dl.StopDownload(newID)
+			continue
+		}
+
+		ids = append(ids, newID)
+
+		wg.Add(1)
+		go func(ch <-chan DownloadEventMsg) {
+			defer wg.Done()
+			for msg := range ch {
+				select {
+				case merged <- msg:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}(ch)
+	}
+
+	go func() {
+		wg.Wait()
+		close(merged)
+	}()
+
+	return merged, ids, nil
+}
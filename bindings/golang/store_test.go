@@ -0,0 +1,173 @@
+package tthsd
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func openTestStore(t *testing.T, opts ...StoreOption) *Store {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "tasks.db")
+	s, err := OpenStore(path, opts...)
+	if err != nil {
+		t.Fatalf("OpenStore 失败: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+// TestStorePutGetRoundTrip 验证 Put 写入的记录可以通过 Get 原样读回。
+func TestStorePutGetRoundTrip(t *testing.T) {
+	s := openTestStore(t)
+
+	rec := TaskRecord{
+		ID:           "task-1",
+		DownloaderID: 7,
+		URL:          "https://example.com/a.bin",
+		SavePath:     "/tmp/a.bin",
+		Status:       StatusReady,
+		Options:      DefaultOptions(),
+	}
+	if err := s.Put(rec); err != nil {
+		t.Fatalf("Put 失败: %v", err)
+	}
+
+	got, ok, err := s.Get("task-1")
+	if err != nil || !ok {
+		t.Fatalf("Get 失败: ok=%v err=%v", ok, err)
+	}
+	if got.URL != rec.URL || got.DownloaderID != rec.DownloaderID || got.Status != rec.Status {
+		t.Fatalf("读回的记录与写入不符: %+v", got)
+	}
+}
+
+// TestStorePutUpsertOverwrites 验证对同一 ID 再次 Put 会覆盖旧记录而不是新增一行。
+func TestStorePutUpsertOverwrites(t *testing.T) {
+	s := openTestStore(t)
+
+	base := TaskRecord{ID: "task-1", URL: "https://example.com/a.bin", Status: StatusReady}
+	_ = s.Put(base)
+
+	base.Status = StatusDone
+	base.Downloaded = 1024
+	_ = s.Put(base)
+
+	got, ok, err := s.Get("task-1")
+	if err != nil || !ok {
+		t.Fatalf("Get 失败: ok=%v err=%v", ok, err)
+	}
+	if got.Status != StatusDone || got.Downloaded != 1024 {
+		t.Fatalf("覆盖写入未生效: %+v", got)
+	}
+
+	all, err := s.List()
+	if err != nil {
+		t.Fatalf("List 失败: %v", err)
+	}
+	if len(all) != 1 {
+		t.Fatalf("预期只有 1 条记录，实际 %d 条", len(all))
+	}
+}
+
+// TestStoreDelete 验证 Delete 之后 Get 返回 ok=false。
+func TestStoreDelete(t *testing.T) {
+	s := openTestStore(t)
+	_ = s.Put(TaskRecord{ID: "task-1", URL: "https://example.com/a.bin"})
+
+	if err := s.Delete("task-1"); err != nil {
+		t.Fatalf("Delete 失败: %v", err)
+	}
+	if _, ok, err := s.Get("task-1"); err != nil || ok {
+		t.Fatalf("Delete 之后记录本应不存在: ok=%v err=%v", ok, err)
+	}
+}
+
+// TestStoreQueueUpdateFlushesOnClose 验证 queueUpdate 攒的进度更新即使还没到
+// flushInterval，也会在 Close 时被落盘，不会丢失。
+func TestStoreQueueUpdateFlushesOnClose(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.db")
+	s, err := OpenStore(path, WithFlushInterval(time.Hour))
+	if err != nil {
+		t.Fatalf("OpenStore 失败: %v", err)
+	}
+	_ = s.Put(TaskRecord{ID: "task-1", URL: "https://example.com/a.bin", Status: StatusReady})
+
+	s.queueUpdate(TaskRecord{ID: "task-1", URL: "https://example.com/a.bin", Status: StatusRunning, Downloaded: 512, Total: 2048})
+
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close 失败: %v", err)
+	}
+
+	// 重新打开同一个文件，确认 Close 之前排队的更新确实落盘了。
+	s2, err := OpenStore(path)
+	if err != nil {
+		t.Fatalf("重新打开 Store 失败: %v", err)
+	}
+	defer s2.Close()
+
+	got, ok, err := s2.Get("task-1")
+	if err != nil || !ok {
+		t.Fatalf("Get 失败: ok=%v err=%v", ok, err)
+	}
+	if got.Downloaded != 512 || got.Total != 2048 {
+		t.Fatalf("排队的进度更新未在 Close 时落盘: %+v", got)
+	}
+}
+
+// TestStoreObserveUpdateQueuesProgress 验证 update 事件走批量缓冲区，
+// 不会立即写盘（需要等 flush/Close）。
+func TestStoreObserveUpdateQueuesProgress(t *testing.T) {
+	s := openTestStore(t, WithFlushInterval(time.Hour))
+	_ = s.Put(TaskRecord{ID: "task-1", URL: "https://example.com/a.bin", Status: StatusReady})
+
+	s.observe(DownloadEventMsg{
+		Event:   DownloadEvent{Type: EventUpdate, ID: "task-1"},
+		Payload: &UpdateData{Downloaded: 100, Total: 1000},
+	})
+
+	s.mu.Lock()
+	_, pending := s.pending["task-1"]
+	s.mu.Unlock()
+	if !pending {
+		t.Fatal("update 事件应当进入 pending 批量缓冲区")
+	}
+
+	s.flush()
+	got, ok, err := s.Get("task-1")
+	if err != nil || !ok {
+		t.Fatalf("Get 失败: ok=%v err=%v", ok, err)
+	}
+	if got.Downloaded != 100 || got.Total != 1000 || got.Status != StatusRunning {
+		t.Fatalf("flush 之后记录未更新: %+v", got)
+	}
+}
+
+// TestStoreObserveTerminalStatesPersistImmediately 验证 endOne/err 这类终态
+// 事件立即落盘，不经过批量缓冲区（避免进程退出前丢失终态）。
+func TestStoreObserveTerminalStatesPersistImmediately(t *testing.T) {
+	s := openTestStore(t, WithFlushInterval(time.Hour))
+	_ = s.Put(TaskRecord{ID: "task-1", URL: "https://example.com/a.bin", Status: StatusRunning})
+
+	s.observe(DownloadEventMsg{Event: DownloadEvent{Type: EventEndOne, ID: "task-1"}})
+
+	got, ok, err := s.Get("task-1")
+	if err != nil || !ok {
+		t.Fatalf("Get 失败: ok=%v err=%v", ok, err)
+	}
+	if got.Status != StatusDone {
+		t.Fatalf("endOne 事件应立即把状态置为 done: %+v", got)
+	}
+}
+
+// TestStoreObserveUnknownTaskIgnored 验证 observe 收到未持久化过的任务 ID 时
+// 直接忽略，不会意外创建新记录。
+func TestStoreObserveUnknownTaskIgnored(t *testing.T) {
+	s := openTestStore(t)
+
+	s.observe(DownloadEventMsg{Event: DownloadEvent{Type: EventUpdate, ID: "never-persisted"}})
+
+	if _, ok, _ := s.Get("never-persisted"); ok {
+		t.Fatal("observe 不应为未知任务 ID 创建记录")
+	}
+}
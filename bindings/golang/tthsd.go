@@ -9,6 +9,7 @@ import "C"
 import (
 	"encoding/json"
 	"fmt"
+	"strconv"
 	"sync"
 
 	"github.com/google/uuid"
@@ -18,10 +19,56 @@ import (
 // 因为 C 回调不携带 userdata 指针，使用全局 map 做 ID -> channel 路由
 
 var (
-	callbackMu   sync.RWMutex
+	callbackMu    sync.RWMutex
 	callbackChans = make(map[int]chan DownloadEventMsg)
+
+	// taskIndexMu 保护下面两个 map：任务 UUID <-> 下载器 ID 的双向索引，
+	// 供 goCallbackBridge 把事件路由到正确的下载器 channel。
+	taskIndexMu      sync.RWMutex
+	taskToDownloader = make(map[string]int)
+	downloaderTasks  = make(map[int][]string)
+
+	// monitorTapsMu 保护 monitorTaps：每个下载器 ID 上挂的旁路 channel，
+	// 供 Monitor 在不打扰 callbackChans 主消费者的前提下复制一份事件流。
+	monitorTapsMu sync.Mutex
+	monitorTaps   = make(map[int][]chan DownloadEventMsg)
+
+	// observersMu 保护 observers：不区分下载器 ID、收到全部事件的全局观察者
+	// 列表，供 tthsd/metrics 这类旁路统计组件使用。
+	observersMu    sync.RWMutex
+	observers      = make(map[int]func(DownloadEventMsg))
+	nextObserverID int
 )
 
+// Subscribe 注册一个全局事件观察者，在每次回调时同步调用，收到所有下载器的
+// 全部事件（不经过 ID 路由，也不受 channel 缓冲区大小限制）。回调应尽快返回，
+// 避免拖慢 C 回调线程；多次调用会叠加多个观察者。
+//
+// 返回的 unsubscribe 函数用于移除该观察者；调用方（例如重复调用
+// metrics.Attach、或在测试里反复 Subscribe）必须在不再需要时调用它，
+// 否则观察者会在进程生命周期内一直叠加，重复统计每一个事件。
+func Subscribe(observer func(DownloadEventMsg)) (unsubscribe func()) {
+	observersMu.Lock()
+	id := nextObserverID
+	nextObserverID++
+	observers[id] = observer
+	observersMu.Unlock()
+
+	return func() {
+		observersMu.Lock()
+		delete(observers, id)
+		observersMu.Unlock()
+	}
+}
+
+func notifyObservers(msg DownloadEventMsg) {
+	observersMu.RLock()
+	defer observersMu.RUnlock()
+	for _, obs := range observers {
+		obs(msg)
+	}
+}
+
 //export goCallbackBridge
 func goCallbackBridge(eventJSON *C.char, dataJSON *C.char) {
 	var eStr, dStr string
@@ -32,20 +79,58 @@ func goCallbackBridge(eventJSON *C.char, dataJSON *C.char) {
 		dStr = C.GoString(dataJSON)
 	}
 
-	event, data := parseCallback(eStr, dStr)
-	msg := DownloadEventMsg{Event: event, Data: data}
+	event, raw, payload := parseCallback(eStr, dStr)
+	msg := DownloadEventMsg{Event: event, Payload: payload, Raw: raw}
 
-	callbackMu.RLock()
-	defer callbackMu.RUnlock()
+	notifyObservers(msg)
+	dispatchEvent(event.ID, msg)
+}
 
-	// 广播到所有已注册的 channel
-	for _, ch := range callbackChans {
+// dispatchEvent 把一条事件投递到 event.ID 对应下载器的 channel。
+//
+// event.ID 在任务级事件（update/startOne/endOne/err）中是 buildTasksJSON
+// 生成的任务 UUID，通过 taskToDownloader 索引查下载器 ID；在会话级事件
+// （start/end/没有任务 ID 的 msg）中，原生库会把该 ID 原样回传成下载器 ID
+// 的字符串形式，直接按整数解析后路由。查不到归属下载器时丢弃该事件。
+func dispatchEvent(eventID string, msg DownloadEventMsg) {
+	downloaderID, ok := lookupDownloaderID(eventID)
+	if !ok {
+		if n, err := strconv.Atoi(eventID); err == nil {
+			downloaderID, ok = n, true
+		}
+	}
+	if !ok {
+		return
+	}
+
+	callbackMu.RLock()
+	if ch, exists := callbackChans[downloaderID]; exists {
 		select {
 		case ch <- msg:
 		default:
 			// channel 满时丢弃（避免阻塞 C 回调线程）
 		}
 	}
+	callbackMu.RUnlock()
+
+	monitorTapsMu.Lock()
+	for _, tap := range monitorTaps[downloaderID] {
+		select {
+		case tap <- msg:
+		default:
+		}
+	}
+	monitorTapsMu.Unlock()
+}
+
+// downloaderExists 判断 id 当前是否对应一个存活的下载器（即 callbackChans
+// 里还登记着它的主 channel）。用于在创建旁路消费者（Monitor/Events）之前
+// 校验 id 的合法性，避免为任意调用方传入的整数常驻创建 goroutine/channel。
+func downloaderExists(id int) bool {
+	callbackMu.RLock()
+	_, ok := callbackChans[id]
+	callbackMu.RUnlock()
+	return ok
 }
 
 func registerChannel(id int) <-chan DownloadEventMsg {
@@ -63,6 +148,59 @@ func unregisterChannel(id int) {
 		delete(callbackChans, id)
 	}
 	callbackMu.Unlock()
+
+	unregisterMonitorTaps(id)
+}
+
+// registerMonitorTap 为 id 新增一个旁路 channel，用于 Monitor 独立消费事件流
+// 而不影响 callbackChans 的主消费者。
+func registerMonitorTap(id int) <-chan DownloadEventMsg {
+	ch := make(chan DownloadEventMsg, 256)
+	monitorTapsMu.Lock()
+	monitorTaps[id] = append(monitorTaps[id], ch)
+	monitorTapsMu.Unlock()
+	return ch
+}
+
+// unregisterMonitorTaps 关闭并清除 id 名下的全部旁路 channel。
+func unregisterMonitorTaps(id int) {
+	monitorTapsMu.Lock()
+	for _, ch := range monitorTaps[id] {
+		close(ch)
+	}
+	delete(monitorTaps, id)
+	monitorTapsMu.Unlock()
+}
+
+// registerTaskIDs 记录一批任务 UUID 归属的下载器 ID，供 dispatchEvent 查询。
+func registerTaskIDs(downloaderID int, taskIDs []string) {
+	taskIndexMu.Lock()
+	defer taskIndexMu.Unlock()
+	for _, id := range taskIDs {
+		if id == "" {
+			continue
+		}
+		taskToDownloader[id] = downloaderID
+	}
+	downloaderTasks[downloaderID] = taskIDs
+}
+
+// unregisterTaskIDs 清除某个下载器名下的全部任务 UUID 索引。
+func unregisterTaskIDs(downloaderID int) {
+	taskIndexMu.Lock()
+	defer taskIndexMu.Unlock()
+	for _, id := range downloaderTasks[downloaderID] {
+		delete(taskToDownloader, id)
+	}
+	delete(downloaderTasks, downloaderID)
+}
+
+// lookupDownloaderID 根据任务 UUID 查找其所属的下载器 ID。
+func lookupDownloaderID(taskID string) (int, bool) {
+	taskIndexMu.RLock()
+	defer taskIndexMu.RUnlock()
+	id, ok := taskToDownloader[taskID]
+	return id, ok
 }
 
 // ---- DownloadOptions ----
@@ -91,6 +229,53 @@ func DefaultOptions() DownloadOptions {
 // TTHSDownloader 是 TTHSD 高速下载器的 Go 封装
 type TTHSDownloader struct {
 	lib *nativeLib
+
+	// tasksMu 保护 taskIDs：本下载器实例提交过的任务 UUID -> 下载器 ID。
+	tasksMu sync.RWMutex
+	taskIDs map[string]int
+
+	// store 为可选的持久化存储，通过 AttachStore 绑定后，
+	// StartDownload/GetDownloader 提交的任务会自动落盘，进度更新会被旁路记录。
+	store *Store
+}
+
+// AttachStore 绑定一个 Store，之后提交的任务会持久化，Resume 也依赖于它恢复任务。
+func (dl *TTHSDownloader) AttachStore(store *Store) {
+	dl.store = store
+}
+
+// persistNewTasks 在绑定了 Store 时把新提交的任务落盘，并把事件 channel
+// 旁路一份给 Store 用于同步进度/状态；未绑定 Store 时原样返回 ch。
+//
+// 已经在 Store 中存在同一 UUID 记录时（例如 Resume 把未完成任务重新提交
+// 给原生库），沿用其 Downloaded/Total，避免把已持久化的下载进度清零；
+// 只有真正的新任务才从零值开始。
+func (dl *TTHSDownloader) persistNewTasks(
+	downloaderID int,
+	urls, savePaths, taskIDs []string,
+	status TaskStatus,
+	opts DownloadOptions,
+	ch <-chan DownloadEventMsg,
+) <-chan DownloadEventMsg {
+	if dl.store == nil {
+		return ch
+	}
+
+	for i, tid := range taskIDs {
+		rec := TaskRecord{
+			ID:           tid,
+			DownloaderID: downloaderID,
+			URL:          urls[i],
+			SavePath:     savePaths[i],
+			Status:       status,
+			Options:      opts,
+		}
+		if existing, ok, err := dl.store.Get(tid); err == nil && ok {
+			rec.Downloaded, rec.Total = existing.Downloaded, existing.Total
+		}
+		dl.store.Put(rec)
+	}
+	return dl.store.teeEvents(ch)
 }
 
 // Load 加载 TTHSD 动态库
@@ -102,7 +287,7 @@ func Load(libPath string) (*TTHSDownloader, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &TTHSDownloader{lib: lib}, nil
+	return &TTHSDownloader{lib: lib, taskIDs: make(map[string]int)}, nil
 }
 
 // Close 释放动态库资源
@@ -113,10 +298,11 @@ func (dl *TTHSDownloader) Close() {
 	}
 }
 
-// buildTasksJSON 构建任务列表 JSON
-func buildTasksJSON(urls, savePaths []string, showNames, ids []string) (string, error) {
+// buildTasksJSON 构建任务列表 JSON，同时返回每个任务最终使用的 UUID
+// （即 ids 为空时自动生成的那一份），供调用方建立任务索引。
+func buildTasksJSON(urls, savePaths []string, showNames, ids []string) (string, []string, error) {
 	if len(urls) != len(savePaths) {
-		return "", fmt.Errorf("[TTHSD] urls 与 savePaths 长度不一致: %d vs %d",
+		return "", nil, fmt.Errorf("[TTHSD] urls 与 savePaths 长度不一致: %d vs %d",
 			len(urls), len(savePaths))
 	}
 
@@ -162,11 +348,16 @@ func buildTasksJSON(urls, savePaths []string, showNames, ids []string) (string,
 		}
 	}
 
+	taskIDs := make([]string, len(tasks))
+	for i, t := range tasks {
+		taskIDs[i] = t.ID
+	}
+
 	data, err := json.Marshal(tasks)
 	if err != nil {
-		return "", fmt.Errorf("[TTHSD] JSON 序列化失败: %w", err)
+		return "", nil, fmt.Errorf("[TTHSD] JSON 序列化失败: %w", err)
 	}
-	return string(data), nil
+	return string(data), taskIDs, nil
 }
 
 // StartDownload 创建并立即启动下载
@@ -175,12 +366,20 @@ func buildTasksJSON(urls, savePaths []string, showNames, ids []string) (string,
 func (dl *TTHSDownloader) StartDownload(
 	urls, savePaths []string,
 	opts DownloadOptions,
+) (int, <-chan DownloadEventMsg, error) {
+	return dl.startDownload(urls, savePaths, nil, opts)
+}
+
+// startDownload 是 StartDownload 的内部实现，允许调用方指定任务 UUID。
+func (dl *TTHSDownloader) startDownload(
+	urls, savePaths, ids []string,
+	opts DownloadOptions,
 ) (int, <-chan DownloadEventMsg, error) {
 	if dl.lib == nil {
 		return -1, nil, fmt.Errorf("[TTHSD] 库未加载")
 	}
 
-	tasksJSON, err := buildTasksJSON(urls, savePaths, nil, nil)
+	tasksJSON, taskIDs, err := buildTasksJSON(urls, savePaths, nil, ids)
 	if err != nil {
 		return -1, nil, err
 	}
@@ -204,7 +403,9 @@ func (dl *TTHSDownloader) StartDownload(
 		return -1, nil, fmt.Errorf("[TTHSD] start_download 失败（返回 -1）")
 	}
 
+	dl.recordTaskIDs(id, taskIDs)
 	ch := registerChannel(id)
+	ch = dl.persistNewTasks(id, urls, savePaths, taskIDs, StatusRunning, opts, ch)
 	return id, ch, nil
 }
 
@@ -214,12 +415,21 @@ func (dl *TTHSDownloader) StartDownload(
 func (dl *TTHSDownloader) GetDownloader(
 	urls, savePaths []string,
 	opts DownloadOptions,
+) (int, <-chan DownloadEventMsg, error) {
+	return dl.getDownloader(urls, savePaths, nil, opts)
+}
+
+// getDownloader 是 GetDownloader 的内部实现，允许调用方指定任务 UUID
+// （Resume 恢复任务时用它沿用持久化记录里的原 ID）。
+func (dl *TTHSDownloader) getDownloader(
+	urls, savePaths, ids []string,
+	opts DownloadOptions,
 ) (int, <-chan DownloadEventMsg, error) {
 	if dl.lib == nil {
 		return -1, nil, fmt.Errorf("[TTHSD] 库未加载")
 	}
 
-	tasksJSON, err := buildTasksJSON(urls, savePaths, nil, nil)
+	tasksJSON, taskIDs, err := buildTasksJSON(urls, savePaths, nil, ids)
 	if err != nil {
 		return -1, nil, err
 	}
@@ -243,7 +453,9 @@ func (dl *TTHSDownloader) GetDownloader(
 		return -1, nil, fmt.Errorf("[TTHSD] get_downloader 失败（返回 -1）")
 	}
 
+	dl.recordTaskIDs(id, taskIDs)
 	ch := registerChannel(id)
+	ch = dl.persistNewTasks(id, urls, savePaths, taskIDs, StatusReady, opts, ch)
 	return id, ch, nil
 }
 
@@ -267,9 +479,59 @@ func (dl *TTHSDownloader) ResumeDownload(id int) bool {
 	return dl.lib.callIntInt(dl.lib.fnResumeDownload, id) == 0
 }
 
+// OwnsTask 判断 taskID 是否是本下载器实例提交过的任务。供 metrics.Attach
+// 这类需要从 Subscribe 的全局事件流中按实例过滤的旁路消费者使用，避免把
+// 同一进程内其他 TTHSDownloader 实例的事件也算到自己头上。
+func (dl *TTHSDownloader) OwnsTask(taskID string) bool {
+	dl.tasksMu.RLock()
+	defer dl.tasksMu.RUnlock()
+	_, ok := dl.taskIDs[taskID]
+	return ok
+}
+
+// IsActive 判断 id 当前是否对应一个存活的下载器（已提交给原生库、尚未
+// StopDownload）。Monitor/Events 之类的旁路消费者应在订阅前先做此校验，
+// 避免为一个从未存在或已经停止的 id 常驻创建 goroutine/channel。
+func (dl *TTHSDownloader) IsActive(id int) bool {
+	return downloaderExists(id)
+}
+
+// Events 返回 id 对应下载器事件流的一份旁路拷贝。与 StartDownload/GetDownloader
+// 返回的主 channel、Monitor 各自独立，可供多个消费者（如 tthsd/gateway）并行订阅。
+func (dl *TTHSDownloader) Events(id int) <-chan DownloadEventMsg {
+	return registerMonitorTap(id)
+}
+
 // StopDownload 停止并销毁下载器
 func (dl *TTHSDownloader) StopDownload(id int) bool {
 	ret := dl.lib.callIntInt(dl.lib.fnStopDownload, id) == 0
 	unregisterChannel(id)
+	dl.forgetTaskIDs(id)
 	return ret
 }
+
+// recordTaskIDs 把一批任务 UUID 登记为某个下载器 ID 名下，
+// 同时更新本实例的索引副本和用于回调路由的全局索引。
+func (dl *TTHSDownloader) recordTaskIDs(downloaderID int, taskIDs []string) {
+	dl.tasksMu.Lock()
+	for _, tid := range taskIDs {
+		if tid == "" {
+			continue
+		}
+		dl.taskIDs[tid] = downloaderID
+	}
+	dl.tasksMu.Unlock()
+	registerTaskIDs(downloaderID, taskIDs)
+}
+
+// forgetTaskIDs 清除某个下载器 ID 名下的全部任务 UUID 索引。
+func (dl *TTHSDownloader) forgetTaskIDs(downloaderID int) {
+	dl.tasksMu.Lock()
+	for tid, did := range dl.taskIDs {
+		if did == downloaderID {
+			delete(dl.taskIDs, tid)
+		}
+	}
+	dl.tasksMu.Unlock()
+	unregisterTaskIDs(downloaderID)
+}
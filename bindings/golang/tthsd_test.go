@@ -0,0 +1,199 @@
+package tthsd
+
+import (
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestDispatchEventChannelIsolation 模拟两次并发 StartDownload：
+// 各自的任务 UUID 只能投递到各自的 channel，互不干扰。
+func TestDispatchEventChannelIsolation(t *testing.T) {
+	const idA, idB = 101, 102
+	taskA := "task-a"
+	taskB := "task-b"
+
+	chA := registerChannel(idA)
+	chB := registerChannel(idB)
+	defer unregisterChannel(idA)
+	defer unregisterChannel(idB)
+
+	registerTaskIDs(idA, []string{taskA})
+	registerTaskIDs(idB, []string{taskB})
+	defer unregisterTaskIDs(idA)
+	defer unregisterTaskIDs(idB)
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		dispatchEvent(taskA, DownloadEventMsg{Event: DownloadEvent{Type: EventUpdate, ID: taskA}})
+	}()
+	go func() {
+		defer wg.Done()
+		dispatchEvent(taskB, DownloadEventMsg{Event: DownloadEvent{Type: EventUpdate, ID: taskB}})
+	}()
+	wg.Wait()
+
+	select {
+	case msg := <-chA:
+		if msg.Event.ID != taskA {
+			t.Fatalf("channel A 收到了不属于自己的事件: %+v", msg)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("channel A 未收到事件")
+	}
+
+	select {
+	case msg := <-chB:
+		if msg.Event.ID != taskB {
+			t.Fatalf("channel B 收到了不属于自己的事件: %+v", msg)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("channel B 未收到事件")
+	}
+
+	// 两个 channel 都不应该再收到对方的事件
+	select {
+	case msg := <-chA:
+		t.Fatalf("channel A 多收到一条事件: %+v", msg)
+	default:
+	}
+	select {
+	case msg := <-chB:
+		t.Fatalf("channel B 多收到一条事件: %+v", msg)
+	default:
+	}
+}
+
+// TestDispatchEventSessionWide 验证没有任务 ID 的会话级事件（start/end/msg）
+// 按下载器 ID 本身路由到所属 channel。
+func TestDispatchEventSessionWide(t *testing.T) {
+	const id = 201
+	ch := registerChannel(id)
+	defer unregisterChannel(id)
+
+	dispatchEvent("201", DownloadEventMsg{Event: DownloadEvent{Type: EventEnd}})
+
+	select {
+	case msg := <-ch:
+		if msg.Event.Type != EventEnd {
+			t.Fatalf("收到了非预期事件: %+v", msg)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("会话级事件未投递到所属 channel")
+	}
+}
+
+// TestStopDownloadForgetsTaskIDs 直接调用 StopDownload 内部执行的
+// unregisterChannel/unregisterTaskIDs，验证之后之前登记的任务 UUID
+// 不应再解析到旧下载器 ID（避免悬挂索引导致误路由）。
+func TestStopDownloadForgetsTaskIDs(t *testing.T) {
+	const id = 301
+	task := "task-forgotten"
+
+	registerChannel(id)
+	registerTaskIDs(id, []string{task})
+	unregisterChannel(id)
+	unregisterTaskIDs(id)
+
+	if _, ok := lookupDownloaderID(task); ok {
+		t.Fatal("unregisterTaskIDs 之后任务索引本应被清除")
+	}
+}
+
+// TestPersistNewTasksPreservesExistingProgress 验证 persistNewTasks 重新提交
+// 一个 Store 中已有进度记录的任务（Resume 场景）时，不会把 Downloaded/Total
+// 清零覆盖，只有真正的新任务才从零值开始。
+func TestPersistNewTasksPreservesExistingProgress(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.db")
+	store, err := OpenStore(path)
+	if err != nil {
+		t.Fatalf("OpenStore 失败: %v", err)
+	}
+	defer store.Close()
+
+	const taskID = "task-resumed"
+	if err := store.Put(TaskRecord{
+		ID: taskID, URL: "https://example.com/a.bin", Status: StatusPaused,
+		Downloaded: 4096, Total: 8192,
+	}); err != nil {
+		t.Fatalf("Put 失败: %v", err)
+	}
+
+	dl := &TTHSDownloader{taskIDs: make(map[string]int), store: store}
+	ch := make(chan DownloadEventMsg)
+	close(ch)
+	_ = dl.persistNewTasks(1, []string{"https://example.com/a.bin"}, []string{"/tmp/a.bin"},
+		[]string{taskID}, StatusReady, DefaultOptions(), ch)
+
+	got, ok, err := store.Get(taskID)
+	if err != nil || !ok {
+		t.Fatalf("Get 失败: ok=%v err=%v", ok, err)
+	}
+	if got.Downloaded != 4096 || got.Total != 8192 {
+		t.Fatalf("重新提交不应清零已持久化的进度: %+v", got)
+	}
+
+	const newTaskID = "task-brand-new"
+	ch2 := make(chan DownloadEventMsg)
+	close(ch2)
+	_ = dl.persistNewTasks(1, []string{"https://example.com/b.bin"}, []string{"/tmp/b.bin"},
+		[]string{newTaskID}, StatusReady, DefaultOptions(), ch2)
+
+	got2, ok, err := store.Get(newTaskID)
+	if err != nil || !ok {
+		t.Fatalf("Get 失败: ok=%v err=%v", ok, err)
+	}
+	if got2.Downloaded != 0 || got2.Total != 0 {
+		t.Fatalf("全新任务应当从零进度开始: %+v", got2)
+	}
+}
+
+// TestOwnsTaskScopesPerInstance 验证 OwnsTask 只认领本实例 recordTaskIDs
+// 登记过的任务 UUID，不会把同一进程内其他 TTHSDownloader 实例的任务
+// 也认作自己的（metrics.Attach 依赖这一点从全局事件流中按实例过滤）。
+func TestOwnsTaskScopesPerInstance(t *testing.T) {
+	dlA := &TTHSDownloader{taskIDs: make(map[string]int)}
+	dlB := &TTHSDownloader{taskIDs: make(map[string]int)}
+
+	dlA.recordTaskIDs(501, []string{"task-a"})
+	defer dlA.forgetTaskIDs(501)
+
+	if !dlA.OwnsTask("task-a") {
+		t.Fatal("dlA 应当认领自己提交过的任务")
+	}
+	if dlB.OwnsTask("task-a") {
+		t.Fatal("dlB 不应认领属于 dlA 的任务")
+	}
+
+	dlA.forgetTaskIDs(501)
+	if dlA.OwnsTask("task-a") {
+		t.Fatal("forgetTaskIDs 之后 OwnsTask 应当返回 false")
+	}
+}
+
+// TestSubscribeUnsubscribeStopsDelivery 验证 Subscribe 返回的 unsubscribe
+// 函数调用之后，该观察者不再收到后续事件（避免重复 Attach/Subscribe 时
+// 旧观察者无法被移除、无限期重复计数）。
+func TestSubscribeUnsubscribeStopsDelivery(t *testing.T) {
+	var count int
+	var mu sync.Mutex
+
+	unsubscribe := Subscribe(func(DownloadEventMsg) {
+		mu.Lock()
+		count++
+		mu.Unlock()
+	})
+
+	notifyObservers(DownloadEventMsg{Event: DownloadEvent{Type: EventMsg}})
+	unsubscribe()
+	notifyObservers(DownloadEventMsg{Event: DownloadEvent{Type: EventMsg}})
+
+	mu.Lock()
+	defer mu.Unlock()
+	if count != 1 {
+		t.Fatalf("unsubscribe 之后不应再收到事件，观察者被调用了 %d 次", count)
+	}
+}
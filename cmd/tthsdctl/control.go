@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/spf13/cobra"
+)
+
+// newControlCmd 构造 pause/resume/stop 这类单 ID 控制命令：它们通过 HTTP
+// 调用一个正在运行的 tthsdctl serve 网关，因为 Pause/Resume/StopDownload
+// 只在加载了原生库的那个进程里有意义。
+func newControlCmd(use, short, gatewayAction string) *cobra.Command {
+	var addr, token string
+
+	cmd := &cobra.Command{
+		Use:   use + " <id>",
+		Short: short,
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if _, err := strconv.Atoi(args[0]); err != nil {
+				return fmt.Errorf("id 必须是整数: %w", err)
+			}
+
+			url := fmt.Sprintf("%s/downloads/%s/%s", addr, args[0], gatewayAction)
+			req, err := http.NewRequest(http.MethodPost, url, nil)
+			if err != nil {
+				return err
+			}
+			if token != "" {
+				req.Header.Set("X-TTHSD-Token", token)
+			}
+
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				return fmt.Errorf("请求网关失败: %w", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode >= 300 {
+				return fmt.Errorf("网关返回 %s", resp.Status)
+			}
+			fmt.Printf("%s %s 成功\n", gatewayAction, args[0])
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&addr, "addr", "http://127.0.0.1:8080", "tthsdctl serve 网关地址")
+	cmd.Flags().StringVar(&token, "token", "", "网关鉴权 Token")
+	return cmd
+}
+
+func newPauseCmd() *cobra.Command {
+	return newControlCmd("pause", "暂停指定 ID 的下载", "pause")
+}
+
+func newResumeCmd() *cobra.Command {
+	return newControlCmd("resume", "恢复指定 ID 的下载", "resume")
+}
+
+func newStopCmd() *cobra.Command {
+	return newControlCmd("stop", "停止指定 ID 的下载", "stop")
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/spf13/cobra"
+
+	tthsd "github.com/TTHSDownloader/TTHSDNext/bindings/golang"
+)
+
+// batchTasks 是 --tasks 指定的批量提交文件格式
+type batchTasks struct {
+	URLs      []string `json:"urls"`
+	SavePaths []string `json:"save_paths"`
+}
+
+func newDownloadCmd() *cobra.Command {
+	var (
+		outputs   []string
+		threads   int
+		chunkMB   int
+		ua        string
+		resume    bool
+		tasksFile string
+		storePath string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "download [urls...]",
+		Short: "下载一批 URL 并在终端显示进度",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			urls, savePaths := args, outputs
+
+			if tasksFile != "" {
+				data, err := os.ReadFile(tasksFile)
+				if err != nil {
+					return fmt.Errorf("读取 --tasks 文件失败: %w", err)
+				}
+				var batch batchTasks
+				if err := json.Unmarshal(data, &batch); err != nil {
+					return fmt.Errorf("解析 --tasks 文件失败: %w", err)
+				}
+				urls, savePaths = batch.URLs, batch.SavePaths
+			}
+
+			if !resume {
+				if len(urls) == 0 {
+					return fmt.Errorf("至少需要一个 URL（命令行参数或 --tasks 文件）")
+				}
+				if len(savePaths) != len(urls) {
+					return fmt.Errorf("-o 数量（%d）必须与 URL 数量（%d）一致", len(savePaths), len(urls))
+				}
+			}
+
+			dl, err := tthsd.Load(libPath)
+			if err != nil {
+				return fmt.Errorf("加载动态库失败: %w", err)
+			}
+			defer dl.Close()
+
+			if storePath != "" {
+				store, err := tthsd.OpenStore(storePath)
+				if err != nil {
+					return fmt.Errorf("打开任务存储失败: %w", err)
+				}
+				defer store.Close()
+				dl.AttachStore(store)
+			}
+
+			opts := tthsd.DownloadOptions{ThreadCount: threads, ChunkSizeMB: chunkMB}
+			if ua != "" {
+				opts.UserAgent = &ua
+			}
+
+			var activeIDs []int
+			var events <-chan tthsd.DownloadEventMsg
+
+			if resume {
+				ch, ids, err := dl.Resume(context.Background())
+				if err != nil {
+					return fmt.Errorf("恢复任务失败: %w", err)
+				}
+				events, activeIDs = ch, ids
+			} else {
+				id, ch, err := dl.StartDownload(urls, savePaths, opts)
+				if err != nil {
+					return fmt.Errorf("启动下载失败: %w", err)
+				}
+				activeIDs, events = []int{id}, ch
+			}
+
+			return watchEvents(dl, activeIDs, events)
+		},
+	}
+
+	cmd.Flags().StringSliceVarP(&outputs, "output", "o", nil, "保存路径，与 URL 按顺序一一对应")
+	cmd.Flags().IntVar(&threads, "threads", 64, "下载线程数")
+	cmd.Flags().IntVar(&chunkMB, "chunk-mb", 10, "分块大小（MB）")
+	cmd.Flags().StringVar(&ua, "ua", "", "自定义 User-Agent")
+	cmd.Flags().BoolVar(&resume, "resume", false, "从 --store 指定的存储恢复未完成任务，忽略 URL 参数")
+	cmd.Flags().StringVar(&tasksFile, "tasks", "", "批量提交用的 tasks.json 文件路径")
+	cmd.Flags().StringVar(&storePath, "store", "", "持久化任务存储路径（SQLite），配合 --resume 使用")
+
+	return cmd
+}
+
+// watchEvents 渲染进度条，直到收到第一个 end 事件或事件 channel 关闭；Ctrl-C 时对 activeIDs 中
+// 的每一个下载器都调用 StopDownload——一次 --resume 可能同时重启多个下载器，
+// 必须全部停止，否则只掐掉其中一个其余的仍会在后台继续下载。
+func watchEvents(dl *tthsd.TTHSDownloader, activeIDs []int, events <-chan tthsd.DownloadEventMsg) error {
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+
+	for {
+		select {
+		case evt, ok := <-events:
+			if !ok {
+				return nil
+			}
+
+			switch evt.Event.Type {
+			case tthsd.EventUpdate:
+				if d, ok := evt.Payload.(*tthsd.UpdateData); ok && d.Total > 0 {
+					pct := float64(d.Downloaded) / float64(d.Total) * 100
+					fmt.Printf("\r[%s] %.1f%%", evt.Event.ShowName, pct)
+				}
+
+			case tthsd.EventEndOne:
+				fmt.Printf("\n✅ 完成: %s\n", evt.Event.ShowName)
+
+			case tthsd.EventErr:
+				if d, ok := evt.Payload.(*tthsd.ErrData); ok {
+					fmt.Fprintf(os.Stderr, "\n❌ [%s] %s\n", d.Code, d.Error)
+				}
+
+			case tthsd.EventEnd:
+				fmt.Println("\n🏁 全部下载完成")
+				return nil
+			}
+
+		case <-sigCh:
+			fmt.Println("\n中断，正在停止下载...")
+			for _, id := range activeIDs {
+				dl.StopDownload(id)
+			}
+			return nil
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	tthsd "github.com/TTHSDownloader/TTHSDNext/bindings/golang"
+)
+
+func newLsCmd() *cobra.Command {
+	var storePath string
+
+	cmd := &cobra.Command{
+		Use:   "ls",
+		Short: "列出持久化任务存储中的全部任务",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if storePath == "" {
+				return fmt.Errorf("必须通过 --store 指定任务存储路径")
+			}
+
+			store, err := tthsd.OpenStore(storePath)
+			if err != nil {
+				return fmt.Errorf("打开任务存储失败: %w", err)
+			}
+			defer store.Close()
+
+			records, err := store.List()
+			if err != nil {
+				return fmt.Errorf("读取任务列表失败: %w", err)
+			}
+
+			for _, rec := range records {
+				fmt.Printf("%s\t%-8s\t%d/%d\t%s\n", rec.ID, rec.Status, rec.Downloaded, rec.Total, rec.URL)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&storePath, "store", "", "持久化任务存储路径（SQLite）")
+	return cmd
+}
@@ -0,0 +1,35 @@
+// Command tthsdctl 是 TTHSD Go 绑定的命令行工具，封装下载提交、暂停/恢复/
+// 停止、查看持久化任务队列以及启动 HTTP/WebSocket 网关。
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+// libPath 是 --lib 全局参数，转发给 tthsd.Load
+var libPath string
+
+func main() {
+	root := &cobra.Command{
+		Use:   "tthsdctl",
+		Short: "TTHSD 高速下载器命令行工具",
+	}
+	root.PersistentFlags().StringVar(&libPath, "lib", "", "TTHSD 动态库路径（留空自动搜索）")
+
+	root.AddCommand(
+		newDownloadCmd(),
+		newPauseCmd(),
+		newResumeCmd(),
+		newStopCmd(),
+		newLsCmd(),
+		newServeCmd(),
+	)
+
+	if err := root.Execute(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
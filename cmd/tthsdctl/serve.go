@@ -0,0 +1,34 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	tthsd "github.com/TTHSDownloader/TTHSDNext/bindings/golang"
+	"github.com/TTHSDownloader/TTHSDNext/bindings/golang/gateway"
+)
+
+func newServeCmd() *cobra.Command {
+	var addr, token string
+
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "以 HTTP/WebSocket 网关模式运行，供 pause/resume/stop 等命令远程控制",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dl, err := tthsd.Load(libPath)
+			if err != nil {
+				return fmt.Errorf("加载动态库失败: %w", err)
+			}
+			defer dl.Close()
+
+			gw := gateway.New(dl, gateway.WithAuthToken(token))
+			fmt.Printf("网关监听于 %s\n", addr)
+			return gw.ListenAndServe(addr)
+		},
+	}
+
+	cmd.Flags().StringVar(&addr, "addr", ":8080", "监听地址")
+	cmd.Flags().StringVar(&token, "token", "", "网关鉴权 Token")
+	return cmd
+}